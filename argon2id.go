@@ -0,0 +1,349 @@
+// Package argon2id provides a convenience wrapper around Go's
+// golang.org/x/crypto/argon2 implementation, making it simpler to securely
+// hash and verify passwords using Argon2. Cryptographically-secure and
+// randomized salts are used by default.
+//
+// Hashes produced by this package follow the format used by the Argon2
+// reference C implementation, and contain the base64-encoded Argon2 derived
+// key prefixed by the variant, version and parameters used to create it. A
+// sample hash looks like this:
+//
+//	$argon2id$v=19$m=65536,t=1,p=2$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG
+//
+// Both the argon2id and argon2i variants can be created and verified;
+// argon2d is not supported because golang.org/x/crypto/argon2 doesn't
+// implement it. A password can optionally be pre-hashed with a fixed-size
+// digest (see PreHashMode) before being passed to Argon2, which removes any
+// practical ceiling on input password length. A password can also be mixed
+// with a pepper before hashing (see Params.KeyID and SecretKeyLookup), which
+// the hash references by KeyID rather than storing, so peppers can be
+// rotated without invalidating existing hashes.
+//
+// There's no support for passing Argon2's own "associated data" parameter.
+// golang.org/x/crypto/argon2 only exposes Key and IDKey, neither of which
+// accept it (it's wired into the unexported deriveKey), so the pepper above
+// is implemented as an HMAC mixed into the password rather than as true
+// keyed Argon2.
+package argon2id
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Variant identifies which Argon2 KDF a Params value (and the hash it
+// produces) uses.
+type Variant string
+
+// The Argon2 variants this package knows how to create and verify.
+const (
+	VariantArgon2id Variant = "argon2id"
+	VariantArgon2i  Variant = "argon2i"
+)
+
+var (
+	// ErrInvalidHash is returned if the provided hash isn't in the expected format.
+	ErrInvalidHash = errors.New("argon2id: hash is not in the correct format")
+
+	// ErrIncompatibleVariant is returned if the provided hash was created using an
+	// Argon2 variant this package doesn't know how to verify.
+	ErrIncompatibleVariant = errors.New("argon2id: incompatible variant of argon2")
+
+	// ErrIncompatibleVersion is returned if the provided hash was created using a
+	// different version of Argon2.
+	ErrIncompatibleVersion = errors.New("argon2id: incompatible version of argon2")
+
+	// ErrIncompatiblePreHash is returned if the provided hash was created using a
+	// password pre-hashing mode this package doesn't know how to apply.
+	ErrIncompatiblePreHash = errors.New("argon2id: incompatible password pre-hash mode")
+)
+
+// Params describes the input parameters used by the Argon2 algorithm. For
+// guidance and an outline process for choosing appropriate parameters see
+// https://tools.ietf.org/html/draft-irtf-cfrg-argon2-04#section-4
+type Params struct {
+	Memory      uint32      // The amount of memory used by the algorithm (in kibibytes).
+	Iterations  uint32      // The number of iterations over the memory.
+	Parallelism uint8       // The number of threads (or lanes) used by the algorithm. Recommended value is between 1 and runtime.NumCPU().
+	SaltLength  uint32      // Length of the random salt. 16 bytes is recommended for password hashing.
+	KeyLength   uint32      // Length of the generated key. 16 bytes or more is recommended.
+	Variant     Variant     // Argon2 variant to use. The zero value is treated as VariantArgon2id.
+	PreHash     PreHashMode // Digest to run the password through before Argon2. The zero value is PreHashNone.
+	KeyID       string      // Identifies the pepper (see SecretKeyLookup) mixed into the password before Argon2. Empty means no pepper. Must only contain ASCII letters, digits, '-' or '_'.
+}
+
+// DefaultParams provides sensible default parameters for hashing passwords.
+var DefaultParams = &Params{
+	Memory:      64 * 1024,
+	Iterations:  1,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+	Variant:     VariantArgon2id,
+}
+
+// CreateHash returns an Argon2 hash of a plain-text password using the
+// provided algorithm parameters. The variant encoded in the hash is taken
+// from params.Variant (defaulting to argon2id). If params.PreHash is set,
+// the password is run through the corresponding digest before Argon2 and its
+// identifier is appended to the variant segment of the hash (e.g.
+// "argon2id-b2b") so DecodeHash can apply the same pre-hash on verification.
+// If params.KeyID is set, the password is first mixed with the pepper it
+// identifies (see SecretKeyLookup), ahead of any pre-hash, and the KeyID is
+// embedded in the hash's
+// parameter field (e.g. "m=65536,t=1,p=2,k=2") so CheckHash knows which
+// pepper to look up again. KeyID may only contain ASCII letters, digits,
+// '-' and '_'; anything else returns ErrInvalidKeyID, since it would
+// otherwise corrupt the field it's embedded in.
+func CreateHash(password string, params *Params) (hash string, err error) {
+	variant := params.Variant
+	if variant == "" {
+		variant = VariantArgon2id
+	}
+
+	if !validKeyID(params.KeyID) {
+		return "", ErrInvalidKeyID
+	}
+
+	mixed, err := mixSecret(params.KeyID, []byte(password))
+	if err != nil {
+		return "", err
+	}
+
+	digest, err := preHash(params.PreHash, mixed)
+	if err != nil {
+		return "", err
+	}
+
+	salt, err := GenerateRandomBytes(params.SaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := deriveKey(variant, digest, salt, params)
+	if err != nil {
+		return "", err
+	}
+
+	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
+	b64Key := base64.RawStdEncoding.EncodeToString(key)
+
+	hash = fmt.Sprintf("$%s$v=%d$%s$%s$%s", variantSegment(variant, params.PreHash), argon2.Version, paramsField(params), b64Salt, b64Key)
+	return hash, nil
+}
+
+// CheckHash is like ComparePasswordAndHash, except it also returns the params that
+// the hash was created with. This can be useful if you want to update your
+// hash params over time (which you should).
+func CheckHash(password, hash string) (match bool, params *Params, err error) {
+	params, salt, key, err := DecodeHash(hash)
+	if err != nil {
+		return false, nil, err
+	}
+
+	mixed, err := mixSecret(params.KeyID, []byte(password))
+	if err != nil {
+		return false, params, err
+	}
+
+	digest, err := preHash(params.PreHash, mixed)
+	if err != nil {
+		return false, params, err
+	}
+
+	otherKey, err := deriveKey(params.Variant, digest, salt, params)
+	if err != nil {
+		return false, params, err
+	}
+
+	keyLen := int32(len(key))
+	otherKeyLen := int32(len(otherKey))
+
+	if subtle.ConstantTimeEq(keyLen, otherKeyLen) == 0 {
+		return false, params, nil
+	}
+	if subtle.ConstantTimeCompare(key, otherKey) == 1 {
+		return true, params, nil
+	}
+	return false, params, nil
+}
+
+// ComparePasswordAndHash performs a constant-time comparison between a
+// plain-text password and hash, using the variant, parameters and salt
+// contained in the hash. It returns true if they match, otherwise it returns
+// false.
+func ComparePasswordAndHash(password, hash string) (match bool, err error) {
+	match, _, err = CheckHash(password, hash)
+	return match, err
+}
+
+// DecodeHash expects a hash created from this package, and parses it to
+// return the params used to create it, as well as the salt and key
+// (password hash).
+func DecodeHash(hash string) (params *Params, salt, key []byte, err error) {
+	vals := strings.Split(hash, "$")
+	if len(vals) != 6 {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	variantID, preHashID := splitVariantSegment(vals[1])
+
+	variant, err := parseVariant(variantID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	preHashMode, err := parsePreHashMode(preHashID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var version int
+	_, err = fmt.Sscanf(vals[2], "v=%d", &version)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, ErrIncompatibleVersion
+	}
+
+	params = &Params{Variant: variant, PreHash: preHashMode}
+	err = parseParamsField(vals[3], params)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	salt, err = base64.RawStdEncoding.Strict().DecodeString(vals[4])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	params.SaltLength = uint32(len(salt))
+
+	key, err = base64.RawStdEncoding.Strict().DecodeString(vals[5])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+// parseVariant maps the PHC variant identifier embedded in a hash (e.g.
+// "argon2id") onto the Variant constants this package can derive keys for.
+func parseVariant(id string) (Variant, error) {
+	switch Variant(id) {
+	case VariantArgon2id:
+		return VariantArgon2id, nil
+	case VariantArgon2i:
+		return VariantArgon2i, nil
+	default:
+		return "", ErrIncompatibleVariant
+	}
+}
+
+// variantSegment builds the first field of the PHC string, appending the
+// pre-hash identifier (if any) to the variant, e.g. "argon2id-b2b".
+func variantSegment(variant Variant, mode PreHashMode) string {
+	if mode == PreHashNone {
+		return string(variant)
+	}
+	return string(variant) + "-" + string(mode)
+}
+
+// splitVariantSegment is the inverse of variantSegment.
+func splitVariantSegment(segment string) (variantID, preHashID string) {
+	if i := strings.IndexByte(segment, '-'); i >= 0 {
+		return segment[:i], segment[i+1:]
+	}
+	return segment, ""
+}
+
+// paramsField builds the fourth field of the PHC string: the comma-separated
+// "m=,t=,p=" triple, plus a trailing "k=" holding params.KeyID if a pepper
+// was used.
+func paramsField(params *Params) string {
+	field := fmt.Sprintf("m=%d,t=%d,p=%d", params.Memory, params.Iterations, params.Parallelism)
+	if params.KeyID != "" {
+		field += ",k=" + params.KeyID
+	}
+	return field
+}
+
+// parseParamsField is the inverse of paramsField, populating the Memory,
+// Iterations, Parallelism and KeyID fields of params. m, t and p are all
+// required, matching the previous Sscanf("m=%d,t=%d,p=%d", ...)-based
+// parser; a field missing any of them is rejected rather than silently
+// leaving the corresponding Params field zero.
+func parseParamsField(field string, params *Params) error {
+	var sawMemory, sawIterations, sawParallelism bool
+
+	for _, part := range strings.Split(field, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return ErrInvalidHash
+		}
+
+		switch kv[0] {
+		case "m":
+			v, err := strconv.ParseUint(kv[1], 10, 32)
+			if err != nil {
+				return ErrInvalidHash
+			}
+			params.Memory = uint32(v)
+			sawMemory = true
+		case "t":
+			v, err := strconv.ParseUint(kv[1], 10, 32)
+			if err != nil {
+				return ErrInvalidHash
+			}
+			params.Iterations = uint32(v)
+			sawIterations = true
+		case "p":
+			v, err := strconv.ParseUint(kv[1], 10, 8)
+			if err != nil {
+				return ErrInvalidHash
+			}
+			params.Parallelism = uint8(v)
+			sawParallelism = true
+		case "k":
+			params.KeyID = kv[1]
+		default:
+			return ErrInvalidHash
+		}
+	}
+
+	if !sawMemory || !sawIterations || !sawParallelism {
+		return ErrInvalidHash
+	}
+	return nil
+}
+
+// deriveKey runs the Argon2 KDF appropriate for variant over password/salt.
+func deriveKey(variant Variant, password, salt []byte, params *Params) ([]byte, error) {
+	switch variant {
+	case "", VariantArgon2id:
+		return argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength), nil
+	case VariantArgon2i:
+		return argon2.Key(password, salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength), nil
+	default:
+		return nil, ErrIncompatibleVariant
+	}
+}
+
+// GenerateRandomBytes returns n cryptographically-secure random bytes.
+func GenerateRandomBytes(n uint32) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
@@ -3,6 +3,7 @@ package argon2id
 import (
 	"github.com/jgroeneveld/trial/assert"
 	"math/rand"
+	"os"
 	"regexp"
 	"strings"
 	"testing"
@@ -74,6 +75,25 @@ func TestDecodeHash(t *testing.T) {
 	}
 }
 
+func TestDecodeHashMissingParam(t *testing.T) {
+	// Missing the "p=" segment entirely, rather than having it present but
+	// malformed.
+	_, _, _, err := DecodeHash("$argon2id$v=19$m=65536,t=1$UDk0zEuIzbt0x3bwkf8Bgw$ihSfHWUJpTgDvNWiojrgcN4E0pJdUVmqCEdRZesx9tE")
+	if err != ErrInvalidHash {
+		t.Fatalf("expected error %s, got %s", ErrInvalidHash, err)
+	}
+
+	_, _, _, err = DecodeHash("$argon2id$v=19$t=1,p=2$UDk0zEuIzbt0x3bwkf8Bgw$ihSfHWUJpTgDvNWiojrgcN4E0pJdUVmqCEdRZesx9tE")
+	if err != ErrInvalidHash {
+		t.Fatalf("expected error %s, got %s", ErrInvalidHash, err)
+	}
+
+	_, _, _, err = DecodeHash("$argon2id$v=19$m=65536,p=2$UDk0zEuIzbt0x3bwkf8Bgw$ihSfHWUJpTgDvNWiojrgcN4E0pJdUVmqCEdRZesx9tE")
+	if err != ErrInvalidHash {
+		t.Fatalf("expected error %s, got %s", ErrInvalidHash, err)
+	}
+}
+
 func TestCheckHash(t *testing.T) {
 	hash, err := CreateHash("pa$$word", DefaultParams)
 	if err != nil {
@@ -114,15 +134,52 @@ func TestStrictDecoding(t *testing.T) {
 }
 
 func TestVariant(t *testing.T) {
-	// Hash contains wrong variant
-	_, _, err := CheckHash("pa$$word", "$argon2i$v=19$m=65536,t=1,p=2$mFe3kxhovyEByvwnUtr0ow$nU9AqnoPfzMOQhCHa9BDrQ+4bSfj69jgtvGu/2McCxU")
+	// argon2i hashes (e.g. produced by other libraries) are supported.
+	ok, _, err := CheckHash("pa$$word", "$argon2i$v=19$m=65536,t=1,p=2$mFe3kxhovyEByvwnUtr0ow$MeIAzVOHiM1cTlOTTp2iMHZV8fXJfpEfIGv24ug4pfA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected password and hash to match")
+	}
+
+	// argon2d is not supported, so this hash must be rejected.
+	_, _, err = CheckHash("pa$$word", "$argon2d$v=19$m=65536,t=1,p=2$mFe3kxhovyEByvwnUtr0ow$nU9AqnoPfzMOQhCHa9BDrQ+4bSfj69jgtvGu/2McCxU")
 	if err != ErrIncompatibleVariant {
 		t.Fatalf("expected error %s", ErrIncompatibleVariant)
 	}
 }
 
+func TestCreateHashArgon2i(t *testing.T) {
+	params := &Params{
+		Memory:      DefaultParams.Memory,
+		Iterations:  DefaultParams.Iterations,
+		Parallelism: DefaultParams.Parallelism,
+		SaltLength:  DefaultParams.SaltLength,
+		KeyLength:   DefaultParams.KeyLength,
+		Variant:     VariantArgon2i,
+	}
+
+	hash, err := CreateHash("pa$$word", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2i$") {
+		t.Errorf("hash %q does not have the argon2i prefix", hash)
+	}
+
+	match, err := ComparePasswordAndHash("pa$$word", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match {
+		t.Error("expected password and hash to match")
+	}
+}
+
 func TestHashLambdaAndMatchLambda(t *testing.T) {
-	password := GenerateRandomString(10)
+	password := GenerateRandomString(MinPasswordLength)
 	hash, err := HashLambda(password)
 	assert.Nil(t, err)
 
@@ -132,7 +189,29 @@ func TestHashLambdaAndMatchLambda(t *testing.T) {
 	assert.True(t, match)
 }
 
+func TestHashLambdaMinPasswordLength(t *testing.T) {
+	tooShort := GenerateRandomString(MinPasswordLength - 1)
+
+	_, err := HashLambda(tooShort)
+	assert.Equal(t, ErrPasswordTooShort, err)
+
+	_, err = MatchLambda(tooShort, "")
+	assert.Equal(t, ErrPasswordTooShort, err)
+
+	atMinimum := GenerateRandomString(MinPasswordLength)
+
+	hash, err := HashLambda(atMinimum)
+	assert.Nil(t, err)
+
+	match, err := MatchLambda(atMinimum, hash)
+	assert.Nil(t, err)
+	assert.True(t, match)
+}
+
 func TestHashLambdaPasswordBoundaryValues(t *testing.T) {
+	// HashLambda pre-hashes with BLAKE2b before deriving the Argon2id key,
+	// so passwords longer than the historical LambdaKeyLength ceiling hash
+	// and verify just as well as shorter ones.
 	passwordLengthMinus1 := GenerateRandomString(LambdaKeyLength - 1)
 	passwordLengthEqual := GenerateRandomString(LambdaKeyLength)
 	passwordLengthPlus1 := GenerateRandomString(LambdaKeyLength + 1)
@@ -148,9 +227,345 @@ func TestHashLambdaPasswordBoundaryValues(t *testing.T) {
 	assert.True(t, len(hash2) > 10)
 
 	hash3, err := HashLambda(passwordLengthPlus1)
-	assert.NotNil(t, err)
+	assert.Nil(t, err)
+
+	assert.True(t, len(hash3) > 10)
+
+	match, err := MatchLambda(passwordLengthPlus1, hash3)
+	assert.Nil(t, err)
+	assert.True(t, match)
+}
+
+func TestCreateHashPreHashBlake2b(t *testing.T) {
+	params := &Params{
+		Memory:      DefaultParams.Memory,
+		Iterations:  DefaultParams.Iterations,
+		Parallelism: DefaultParams.Parallelism,
+		SaltLength:  DefaultParams.SaltLength,
+		KeyLength:   DefaultParams.KeyLength,
+		Variant:     VariantArgon2id,
+		PreHash:     PreHashBlake2b,
+	}
+
+	longPassword := GenerateRandomString(10_000)
+
+	hash, err := CreateHash(longPassword, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id-b2b$") {
+		t.Errorf("hash %q does not have the argon2id-b2b prefix", hash)
+	}
+
+	decoded, _, _, err := DecodeHash(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.PreHash != PreHashBlake2b {
+		t.Errorf("expected decoded PreHash to be %q, got %q", PreHashBlake2b, decoded.PreHash)
+	}
+
+	match, err := ComparePasswordAndHash(longPassword, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match {
+		t.Error("expected password and hash to match")
+	}
+
+	match, err = ComparePasswordAndHash(longPassword+"x", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match {
+		t.Error("expected different password to not match")
+	}
+}
+
+func TestCreateHashWithPepper(t *testing.T) {
+	t.Setenv("ARGON2ID_SECRET_1", "super-secret-pepper")
+
+	params := &Params{
+		Memory:      DefaultParams.Memory,
+		Iterations:  DefaultParams.Iterations,
+		Parallelism: DefaultParams.Parallelism,
+		SaltLength:  DefaultParams.SaltLength,
+		KeyLength:   DefaultParams.KeyLength,
+		Variant:     VariantArgon2id,
+		KeyID:       "1",
+	}
+
+	hash, err := CreateHash("pa$$word", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(hash, ",k=1$") {
+		t.Errorf("hash %q does not embed the key id", hash)
+	}
+
+	decoded, _, _, err := DecodeHash(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.KeyID != "1" {
+		t.Errorf("expected decoded KeyID to be %q, got %q", "1", decoded.KeyID)
+	}
+
+	match, err := ComparePasswordAndHash("pa$$word", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match {
+		t.Error("expected password and hash to match")
+	}
+
+	t.Setenv("ARGON2ID_SECRET_1", "a different pepper entirely")
+
+	match, err = ComparePasswordAndHash("pa$$word", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match {
+		t.Error("expected hash to stop matching once the pepper rotates")
+	}
+}
 
-	assert.True(t, len(hash3) == 0)
+func TestCheckHashUnknownKeyID(t *testing.T) {
+	t.Setenv("ARGON2ID_SECRET_1", "super-secret-pepper")
+
+	params := &Params{
+		Memory:      DefaultParams.Memory,
+		Iterations:  DefaultParams.Iterations,
+		Parallelism: DefaultParams.Parallelism,
+		SaltLength:  DefaultParams.SaltLength,
+		KeyLength:   DefaultParams.KeyLength,
+		Variant:     VariantArgon2id,
+		KeyID:       "1",
+	}
+
+	hash, err := CreateHash("pa$$word", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Unsetenv("ARGON2ID_SECRET_1")
+
+	if _, err := ComparePasswordAndHash("pa$$word", hash); err != ErrUnknownSecretKey {
+		t.Fatalf("expected error %s, got %s", ErrUnknownSecretKey, err)
+	}
+}
+
+func TestCreateHashInvalidKeyID(t *testing.T) {
+	for _, keyID := range []string{"a,b", "v2$prod", "has space", "emoji😀"} {
+		params := &Params{
+			Memory:      DefaultParams.Memory,
+			Iterations:  DefaultParams.Iterations,
+			Parallelism: DefaultParams.Parallelism,
+			SaltLength:  DefaultParams.SaltLength,
+			KeyLength:   DefaultParams.KeyLength,
+			Variant:     VariantArgon2id,
+			KeyID:       keyID,
+		}
+
+		if _, err := CreateHash("pa$$word", params); err != ErrInvalidKeyID {
+			t.Errorf("KeyID %q: expected error %s, got %s", keyID, ErrInvalidKeyID, err)
+		}
+	}
+}
+
+func TestKeyRing(t *testing.T) {
+	ring := NewKeyRing()
+	ring.Set("1", []byte("super-secret-pepper"))
+
+	originalLookup := SecretKeyLookup
+	SecretKeyLookup = ring.Lookup
+	defer func() { SecretKeyLookup = originalLookup }()
+
+	params := &Params{
+		Memory:      DefaultParams.Memory,
+		Iterations:  DefaultParams.Iterations,
+		Parallelism: DefaultParams.Parallelism,
+		SaltLength:  DefaultParams.SaltLength,
+		KeyLength:   DefaultParams.KeyLength,
+		Variant:     VariantArgon2id,
+		KeyID:       "1",
+	}
+
+	hash, err := CreateHash("pa$$word", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	match, err := ComparePasswordAndHash("pa$$word", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match {
+		t.Error("expected password and hash to match")
+	}
+
+	if _, err := ring.Lookup("unknown"); err != ErrUnknownSecretKey {
+		t.Fatalf("expected error %s, got %s", ErrUnknownSecretKey, err)
+	}
+}
+
+func TestNeedsRehashKeyIDRotation(t *testing.T) {
+	t.Setenv("ARGON2ID_SECRET_1", "super-secret-pepper")
+
+	oldParams := &Params{
+		Memory:      DefaultParams.Memory,
+		Iterations:  DefaultParams.Iterations,
+		Parallelism: DefaultParams.Parallelism,
+		SaltLength:  DefaultParams.SaltLength,
+		KeyLength:   DefaultParams.KeyLength,
+		Variant:     VariantArgon2id,
+		KeyID:       "1",
+	}
+
+	hash, err := CreateHash("pa$$word", oldParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newParams := &Params{
+		Memory:      oldParams.Memory,
+		Iterations:  oldParams.Iterations,
+		Parallelism: oldParams.Parallelism,
+		SaltLength:  oldParams.SaltLength,
+		KeyLength:   oldParams.KeyLength,
+		Variant:     oldParams.Variant,
+		KeyID:       "2",
+	}
+
+	if !NeedsRehash(hash, newParams) {
+		t.Error("expected hash created under the old KeyID to need a rehash")
+	}
+}
+
+func TestCalibrateParams(t *testing.T) {
+	target := 20 * time.Millisecond
+
+	params, err := CalibrateParams(target, 8*1024, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := CreateHash("pa$$word", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	match, err := ComparePasswordAndHash("pa$$word", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match {
+		t.Error("expected password and hash to match")
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	weakParams := &Params{
+		Memory:      DefaultParams.Memory,
+		Iterations:  DefaultParams.Iterations,
+		Parallelism: DefaultParams.Parallelism,
+		SaltLength:  DefaultParams.SaltLength,
+		KeyLength:   DefaultParams.KeyLength,
+		Variant:     VariantArgon2id,
+	}
+
+	hash, err := CreateHash("pa$$word", weakParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if NeedsRehash(hash, weakParams) {
+		t.Error("expected hash created with target params to not need a rehash")
+	}
+
+	strongerParams := &Params{
+		Memory:      weakParams.Memory * 2,
+		Iterations:  weakParams.Iterations,
+		Parallelism: weakParams.Parallelism,
+		SaltLength:  weakParams.SaltLength,
+		KeyLength:   weakParams.KeyLength,
+		Variant:     weakParams.Variant,
+	}
+
+	if !NeedsRehash(hash, strongerParams) {
+		t.Error("expected hash with weaker memory to need a rehash")
+	}
+
+	differentVariant := &Params{
+		Memory:      weakParams.Memory,
+		Iterations:  weakParams.Iterations,
+		Parallelism: weakParams.Parallelism,
+		SaltLength:  weakParams.SaltLength,
+		KeyLength:   weakParams.KeyLength,
+		Variant:     VariantArgon2i,
+	}
+
+	if !NeedsRehash(hash, differentVariant) {
+		t.Error("expected hash with a different variant to need a rehash")
+	}
+
+	if !NeedsRehash("not a valid hash", weakParams) {
+		t.Error("expected an undecodable hash to need a rehash")
+	}
+
+	zeroValueVariant := &Params{
+		Memory:      weakParams.Memory,
+		Iterations:  weakParams.Iterations,
+		Parallelism: weakParams.Parallelism,
+		SaltLength:  weakParams.SaltLength,
+		KeyLength:   weakParams.KeyLength,
+	}
+
+	if NeedsRehash(hash, zeroValueVariant) {
+		t.Error("expected target with the zero-value Variant to be treated as VariantArgon2id")
+	}
+}
+
+func TestRehash(t *testing.T) {
+	oldHash, err := CreateHash("pa$$word", DefaultParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newParams := &Params{
+		Memory:      DefaultParams.Memory * 2,
+		Iterations:  DefaultParams.Iterations,
+		Parallelism: DefaultParams.Parallelism,
+		SaltLength:  DefaultParams.SaltLength,
+		KeyLength:   DefaultParams.KeyLength,
+		Variant:     DefaultParams.Variant,
+	}
+
+	newHash, err := Rehash("pa$$word", oldHash, newParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !NeedsRehash(oldHash, newParams) {
+		t.Fatal("expected old hash to need a rehash against newParams")
+	}
+	if NeedsRehash(newHash, newParams) {
+		t.Error("expected rehashed hash to satisfy newParams")
+	}
+
+	match, err := ComparePasswordAndHash("pa$$word", newHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !match {
+		t.Error("expected password to match the rehashed hash")
+	}
+
+	if _, err := Rehash("wrong password", oldHash, newParams); err != ErrPasswordMismatch {
+		t.Fatalf("expected error %s", ErrPasswordMismatch)
+	}
 }
 
 var letters = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
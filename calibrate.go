@@ -0,0 +1,141 @@
+package argon2id
+
+import "time"
+
+// calibrateMinIterations and calibrateMaxIterations bound the iteration
+// count CalibrateParams will search over, per the parameter guidance in
+// https://tools.ietf.org/html/draft-irtf-cfrg-argon2-04#section-4.
+const (
+	calibrateMinIterations = 1
+	calibrateMaxIterations = 64
+)
+
+// CalibrateParams benchmarks the Argon2id KDF on the running host and
+// returns Params whose single-hash cost is close to target: the bisection
+// below stops at the first Iterations value it times within 10% of target.
+// Parallelism and memory are held at the values supplied (memory may be
+// doubled, see below); CalibrateParams searches for an Iterations value
+// by timing a throwaway hash, doubling Iterations until the time taken
+// is at least target, then bisecting within that range. If Iterations
+// reaches calibrateMaxIterations without reaching target, minMemoryKiB
+// is doubled and the search restarts.
+//
+// The 10% window is not guaranteed. If the bisection times no value
+// inside it, the smallest value measured at or above target is returned,
+// so the cost can be well over target; in particular, if a single
+// iteration at minMemoryKiB already takes longer than target, Iterations
+// is 1. Memory is never reduced below minMemoryKiB.
+//
+// This is useful for producing Params that are appropriately expensive
+// for the host actually running the code, rather than relying on a
+// single hand-tuned set of constants across very different deployment
+// targets (Lambda instance sizes, ARM vs x86, local development
+// laptops).
+func CalibrateParams(target time.Duration, minMemoryKiB uint32, parallelism uint8) (*Params, error) {
+	password, salt, err := calibrationMaterial()
+	if err != nil {
+		return nil, err
+	}
+
+	memory := minMemoryKiB
+	for {
+		params := &Params{
+			Memory:      memory,
+			Parallelism: parallelism,
+			SaltLength:  uint32(len(salt)),
+			KeyLength:   DefaultParams.KeyLength,
+			Variant:     VariantArgon2id,
+		}
+
+		iterations, ok, err := calibrateIterations(password, salt, params, target)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			params.Iterations = iterations
+			return params, nil
+		}
+
+		memory *= 2
+	}
+}
+
+// calibrateIterations searches for an Iterations value that makes hashing
+// with params take approximately target. It reuses the single password/salt
+// pair generated by calibrationMaterial for every timed run rather than
+// regenerating random material each time, so that run-to-run variance comes
+// only from the KDF itself.
+func calibrateIterations(password, salt []byte, params *Params, target time.Duration) (iterations uint32, ok bool, err error) {
+	params.Iterations = calibrateMinIterations
+	elapsed, err := benchmarkHash(password, salt, params)
+	if err != nil {
+		return 0, false, err
+	}
+
+	low, high, highElapsed := uint32(0), params.Iterations, elapsed
+
+	for highElapsed < target && high < calibrateMaxIterations {
+		low = high
+		high *= 2
+		if high > calibrateMaxIterations {
+			high = calibrateMaxIterations
+		}
+
+		params.Iterations = high
+		highElapsed, err = benchmarkHash(password, salt, params)
+		if err != nil {
+			return 0, false, err
+		}
+	}
+
+	if highElapsed < target {
+		return 0, false, nil
+	}
+
+	tolerance := target / 10
+	for high-low > 1 {
+		mid := (low + high) / 2
+
+		params.Iterations = mid
+		midElapsed, err := benchmarkHash(password, salt, params)
+		if err != nil {
+			return 0, false, err
+		}
+
+		switch {
+		case midElapsed < target-tolerance:
+			low = mid
+		case midElapsed > target+tolerance:
+			high = mid
+		default:
+			return mid, true, nil
+		}
+	}
+
+	return high, true, nil
+}
+
+// benchmarkHash times a single throwaway key derivation using params.
+func benchmarkHash(password, salt []byte, params *Params) (time.Duration, error) {
+	start := time.Now()
+	if _, err := deriveKey(params.Variant, password, salt, params); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// calibrationMaterial generates one random password and salt to be reused
+// across every timed run in a single CalibrateParams call.
+func calibrationMaterial() (password, salt []byte, err error) {
+	password, err = GenerateRandomBytes(DefaultParams.KeyLength)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	salt, err = GenerateRandomBytes(DefaultParams.SaltLength)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return password, salt, nil
+}
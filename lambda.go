@@ -0,0 +1,57 @@
+package argon2id
+
+import "errors"
+
+// LambdaKeyLength was historically the maximum permitted password length, in
+// bytes, accepted by HashLambda and MatchLambda. It's kept around for
+// backwards compatibility with callers that reference it, but is no longer
+// enforced: HashLambda pre-hashes with BLAKE2b before deriving the Argon2id
+// key, so there's no practical ceiling on password length any more.
+const LambdaKeyLength = 128
+
+// MinPasswordLength is the minimum permitted password length, in bytes,
+// accepted by HashLambda and MatchLambda.
+const MinPasswordLength = 12
+
+// ErrPasswordTooShort is returned by HashLambda and MatchLambda if the
+// supplied password is shorter than MinPasswordLength bytes.
+var ErrPasswordTooShort = errors.New("argon2id: password is too short. Please see MinPasswordLength")
+
+// lambdaParams have been tuned to execute on a base AWS Lambda instance with
+// default memory and CPU settings. The total hash time should take around a
+// second to finish on Lambda. 64MB of memory is required to execute the
+// hashing function in addition to your normal Lambda memory requirements.
+// PreHash is set to PreHashBlake2b so arbitrarily long passwords (long
+// passphrases, pre-stretched HSM/OPRF material) are safe to hash.
+var lambdaParams = &Params{
+	Memory:      64 * 1024, // 64MB of memory is required to perform this hash
+	Iterations:  15,
+	Parallelism: 4,
+	SaltLength:  64,
+	KeyLength:   512,
+	Variant:     VariantArgon2id,
+	PreHash:     PreHashBlake2b,
+}
+
+// HashLambda hashes a password using parameters tuned for a default AWS
+// Lambda instance. It's a convenience wrapper around CreateHash for callers
+// that don't need to choose their own Params. It returns ErrPasswordTooShort
+// for passwords under MinPasswordLength.
+func HashLambda(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", ErrPasswordTooShort
+	}
+
+	return CreateHash(password, lambdaParams)
+}
+
+// MatchLambda compares a user password input against a known hash produced by
+// HashLambda to see if they're equal. It returns ErrPasswordTooShort for
+// passwords under MinPasswordLength.
+func MatchLambda(password, hash string) (bool, error) {
+	if len(password) < MinPasswordLength {
+		return false, ErrPasswordTooShort
+	}
+
+	return ComparePasswordAndHash(password, hash)
+}
@@ -0,0 +1,64 @@
+package argon2id
+
+import "errors"
+
+// ErrPasswordMismatch is returned by Rehash if password does not match
+// oldHash.
+var ErrPasswordMismatch = errors.New("argon2id: password does not match hash")
+
+// NeedsRehash decodes hash and reports whether any of its embedded
+// parameters (variant, memory, iterations, parallelism, salt length, key
+// length or pepper KeyID) differ from the corresponding field on target, in
+// a way that makes hash weaker than target. Applications can call this
+// after a successful login to decide whether to transparently re-hash the
+// password with stronger parameters, or with a rotated pepper.
+//
+// An undecodable hash is treated as needing a rehash, since CreateHash never
+// produces one.
+func NeedsRehash(hash string, target *Params) bool {
+	params, _, _, err := DecodeHash(hash)
+	if err != nil {
+		return true
+	}
+
+	targetVariant := target.Variant
+	if targetVariant == "" {
+		targetVariant = VariantArgon2id
+	}
+
+	switch {
+	case params.Variant != targetVariant:
+		return true
+	case params.Memory < target.Memory:
+		return true
+	case params.Iterations < target.Iterations:
+		return true
+	case params.Parallelism < target.Parallelism:
+		return true
+	case params.SaltLength < target.SaltLength:
+		return true
+	case params.KeyLength < target.KeyLength:
+		return true
+	case params.KeyID != target.KeyID:
+		return true
+	default:
+		return false
+	}
+}
+
+// Rehash verifies password against oldHash and, if it matches, returns a
+// fresh hash of password created with newParams. It's a convenience for the
+// common "upgrade password hashes over time" pattern: verify with whatever
+// parameters produced the stored hash, then re-hash with the application's
+// current parameters.
+func Rehash(password, oldHash string, newParams *Params) (hash string, err error) {
+	match, err := ComparePasswordAndHash(password, oldHash)
+	if err != nil {
+		return "", err
+	}
+	if !match {
+		return "", ErrPasswordMismatch
+	}
+
+	return CreateHash(password, newParams)
+}